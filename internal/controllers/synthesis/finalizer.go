@@ -0,0 +1,104 @@
+package synthesis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	apiv1 "github.com/Azure/eno/api/v1"
+)
+
+// FinalizerResult reports what a Finalizer changed, so CompositionFinalizer knows whether to
+// persist the composition and/or its status before moving on to the next one.
+type FinalizerResult struct {
+	// Updated is true if the finalizer mutated the composition's metadata (e.g. its finalizers).
+	Updated bool
+
+	// StatusUpdated is true if the finalizer mutated comp.Status.
+	StatusUpdated bool
+
+	// Done is true once the finalizer has nothing left to do, allowing its entry to be removed
+	// from comp.Finalizers.
+	Done bool
+}
+
+// Finalizer performs one step of composition cleanup while comp has a DeletionTimestamp.
+type Finalizer func(ctx context.Context, comp *apiv1.Composition) (FinalizerResult, error)
+
+// CompositionFinalizer is a registry of named cleanup steps, modeled after
+// sigs.k8s.io/controller-runtime/pkg/finalizer. Subsystems register their own finalizers here
+// instead of having their cleanup logic tangled into a single controller's Reconcile, which makes
+// it possible to add cleanup hooks (e.g. external system deprovisioning) without forking the
+// controllers that happen to run first.
+type CompositionFinalizer struct {
+	mu    sync.Mutex
+	names []string
+	fns   map[string]Finalizer
+}
+
+// NewCompositionFinalizer returns an empty registry. It's expected to be shared across the
+// controllers that register cleanup steps against it.
+func NewCompositionFinalizer() *CompositionFinalizer {
+	return &CompositionFinalizer{fns: map[string]Finalizer{}}
+}
+
+// Register adds a named finalizer to the registry. It's meant to be called during startup
+// wiring, before the manager starts, so it panics on a duplicate name rather than surfacing a
+// runtime error.
+func (r *CompositionFinalizer) Register(name string, fn Finalizer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.fns[name]; exists {
+		panic(fmt.Sprintf("finalizer %q already registered", name))
+	}
+	r.names = append(r.names, name)
+	r.fns[name] = fn
+}
+
+// Names returns the registered finalizer names, in registration order. Callers that need to strip
+// every known finalizer from a Composition outside of the normal Finalize flow (e.g. draining on
+// shutdown) should use this instead of hardcoding the set of registered names.
+func (r *CompositionFinalizer) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.names...)
+}
+
+// Finalize runs every registered finalizer whose name is present on comp, in registration order.
+// It stops at the first one that's still in progress, or that changed comp, so callers always
+// persist before a later finalizer observes the mutation.
+func (r *CompositionFinalizer) Finalize(ctx context.Context, comp *apiv1.Composition) (FinalizerResult, error) {
+	r.mu.Lock()
+	names := append([]string(nil), r.names...)
+	r.mu.Unlock()
+
+	var result FinalizerResult
+	for _, name := range names {
+		if !controllerutil.ContainsFinalizer(comp, name) {
+			continue
+		}
+
+		res, err := r.fns[name](ctx, comp)
+		if err != nil {
+			return result, fmt.Errorf("running finalizer %q: %w", name, err)
+		}
+
+		result.Updated = result.Updated || res.Updated
+		result.StatusUpdated = result.StatusUpdated || res.StatusUpdated
+
+		if res.Done {
+			if controllerutil.RemoveFinalizer(comp, name) {
+				result.Updated = true
+			}
+			if res.Updated || res.StatusUpdated {
+				return result, nil
+			}
+			continue
+		}
+
+		return result, nil
+	}
+	return result, nil
+}
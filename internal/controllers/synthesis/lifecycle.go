@@ -3,10 +3,13 @@ package synthesis
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/util/flowcontrol"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -18,21 +21,64 @@ import (
 
 type Config struct {
 	SliceCreationQPS float64
+
+	// NodeUnreachableTimeout is how long a node must report NotReady before its
+	// synthesizer pod is considered stranded and force-deleted. Zero disables
+	// this recovery path.
+	NodeUnreachableTimeout time.Duration
+
+	// RemoveFinalizersOnShutdown causes the controller to strip its cleanup finalizers from every
+	// Composition once it detects that its own Deployment is being deleted, so an uninstall
+	// doesn't wedge namespace or CRD deletion. Operators who prefer to leave resources dangling
+	// can leave this disabled.
+	RemoveFinalizersOnShutdown bool
 }
 
+const nodeOutOfServiceTaint = "node.kubernetes.io/out-of-service"
+
+// disruptionTargetCondition mirrors corev1.DisruptionTarget, which newer
+// client-go versions expose as a constant. Spelled out here so this package
+// doesn't depend on a specific client-go vintage.
+const disruptionTargetCondition corev1.PodConditionType = "DisruptionTarget"
+
+// maxPodDisruptionHistory bounds how many PodDisruption entries are retained
+// on a single Synthesis so the status doesn't grow unbounded under churn.
+const maxPodDisruptionHistory = 20
+
+// Composition cleanup finalizers. Each is owned by a single subsystem, which is responsible for
+// both adding it while the composition is live and removing it once its own cleanup is done.
+const (
+	stateRevertFinalizer  = "eno.azure.io/state-revert"
+	sliceCleanupFinalizer = "eno.azure.io/slice-cleanup"
+	podDrainFinalizer     = "eno.azure.io/pod-drain"
+
+	// legacyCleanupFinalizer was the single finalizer used before cleanup was split into the
+	// three above. It's registered purely so Finalize, and drain (through the registry's Names), can
+	// strip it from any Composition that still carries it from before this upgrade - see
+	// finalizeLegacyCleanup.
+	legacyCleanupFinalizer = "eno.azure.io/cleanup"
+)
+
 type podLifecycleController struct {
 	config           *Config
 	client           client.Client
 	createSliceLimit flowcontrol.RateLimiter
+	finalizers       *CompositionFinalizer
+
+	// draining is set once the controller's own Deployment starts deleting. See WatchOwnDeployment.
+	draining atomic.Bool
 }
 
 // NewPodLifecycleController is responsible for creating and deleting pods as needed to synthesize compositions.
-func NewPodLifecycleController(mgr ctrl.Manager, cfg *Config) error {
+// finalizers is shared with the other controllers that register cleanup steps for a Composition.
+func NewPodLifecycleController(mgr ctrl.Manager, cfg *Config, finalizers *CompositionFinalizer) error {
 	c := &podLifecycleController{
 		config:           cfg,
 		client:           mgr.GetClient(),
 		createSliceLimit: flowcontrol.NewTokenBucketRateLimiter(float32(cfg.SliceCreationQPS), 1),
+		finalizers:       finalizers,
 	}
+	c.registerFinalizers()
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&apiv1.Composition{}).
 		Owns(&corev1.Pod{}).
@@ -40,6 +86,17 @@ func NewPodLifecycleController(mgr ctrl.Manager, cfg *Config) error {
 		Complete(c)
 }
 
+// registerFinalizers wires up the cleanup steps this controller runs, including the state-revert
+// and slice-cleanup steps alongside pod-drain; no other controller registers one of these. Other
+// controllers can register further finalizers against the same shared registry without needing
+// to touch this file.
+func (c *podLifecycleController) registerFinalizers() {
+	c.finalizers.Register(legacyCleanupFinalizer, c.finalizeLegacyCleanup)
+	c.finalizers.Register(stateRevertFinalizer, c.finalizeStateRevert)
+	c.finalizers.Register(sliceCleanupFinalizer, c.finalizeSliceCleanup)
+	c.finalizers.Register(podDrainFinalizer, c.finalizePodDrain)
+}
+
 func (c *podLifecycleController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := logr.FromContextOrDiscard(ctx)
 
@@ -52,13 +109,21 @@ func (c *podLifecycleController) Reconcile(ctx context.Context, req ctrl.Request
 
 	// It isn't safe to delete compositions until their resource slices have been cleaned up,
 	// since reconciling resources necessarily requires the composition.
-	if comp.DeletionTimestamp == nil && controllerutil.AddFinalizer(comp, "eno.azure.io/cleanup") {
-		err = c.client.Update(ctx, comp)
+	if comp.DeletionTimestamp == nil {
+		var added bool
+		err := c.patchFinalizers(ctx, comp, func(comp *apiv1.Composition) bool {
+			added = controllerutil.AddFinalizer(comp, stateRevertFinalizer)
+			added = controllerutil.AddFinalizer(comp, sliceCleanupFinalizer) || added
+			added = controllerutil.AddFinalizer(comp, podDrainFinalizer) || added
+			return added
+		})
 		if err != nil {
 			return ctrl.Result{}, fmt.Errorf("updating composition: %w", err)
 		}
-		logger.Info("added cleanup finalizer to composition")
-		return ctrl.Result{}, nil
+		if added {
+			logger.Info("added cleanup finalizers to composition")
+			return ctrl.Result{}, nil
+		}
 	}
 
 	// Delete any unnecessary pods
@@ -78,76 +143,73 @@ func (c *podLifecycleController) Reconcile(ctx context.Context, req ctrl.Request
 	}
 	logger = logger.WithValues("synthesizerName", syn.Name, "synthesizerGeneration", syn.Generation)
 
-	logger, toDelete, exists := shouldDeletePod(logger, comp, syn, pods)
+	logger, reason, toDelete, exists := shouldDeletePod(logger, comp, syn, pods)
 	if toDelete != nil {
+		base := comp.DeepCopy()
+		reason, message := podDisruptionReason(toDelete, reason)
+		if recordPodDisruption(comp, toDelete.Name, reason, message) {
+			if err := c.patchCompositionStatus(ctx, base, comp); err != nil {
+				return ctrl.Result{}, fmt.Errorf("recording pod disruption: %w", err)
+			}
+		}
 		if err := c.client.Delete(ctx, toDelete); err != nil {
 			return ctrl.Result{}, client.IgnoreNotFound(fmt.Errorf("deleting pod: %w", err))
 		}
-		logger.Info("deleted synthesizer pod", "podName", toDelete.Name)
+		logger.Info("deleted synthesizer pod", "podName", toDelete.Name, "disruptionReason", reason)
 		return ctrl.Result{}, nil
 	}
 	if exists {
+		// A stranded pod on a dead node won't naturally hit PodTimeout for a long time,
+		// which leaves the composition blocked behind a node that's never coming back.
+		if pod := currentRunningPod(comp, pods); pod != nil {
+			if reason, unreachable := c.podStrandedByNode(ctx, pod); unreachable {
+				if err := c.forceDeletePod(ctx, pod); err != nil {
+					return ctrl.Result{}, fmt.Errorf("force deleting pod on unreachable node: %w", err)
+				}
+				base := comp.DeepCopy()
+				if recordPodDisruption(comp, pod.Name, reason, "") {
+					if err := c.patchCompositionStatus(ctx, base, comp); err != nil {
+						return ctrl.Result{}, fmt.Errorf("recording pod disruption: %w", err)
+					}
+				}
+				logger.WithValues("reason", reason, "podName", pod.Name, "nodeName", pod.Spec.NodeName).Info("force-recovered synthesizer pod stranded on unhealthy node")
+				synthesPodForceRecovered.WithLabelValues(reason).Inc()
+				return ctrl.Result{Requeue: true}, nil
+			}
+		}
+
 		// The pod is still running.
 		// Poll periodically to check if has timed out.
 		return ctrl.Result{RequeueAfter: syn.Spec.PodTimeout.Duration}, nil
 	}
 
 	if comp.DeletionTimestamp != nil {
-		// If the composition was being synthesized at the time of deletion we need to swap the previous
-		// state back to current. Otherwise we'll get stuck waiting for a synthesis that can't happen.
-		if comp.Status.CurrentState == nil || !comp.Status.CurrentState.Synthesized {
-			comp.Status.CurrentState = comp.Status.PreviousState
-			comp.Status.PreviousState = nil
-			err = c.client.Status().Update(ctx, comp)
-			if err != nil {
-				return ctrl.Result{}, fmt.Errorf("reverting swapped status for deletion: %w", err)
-			}
-			logger.Info("reverted swapped status for deletion")
-			return ctrl.Result{}, nil
+		base := comp.DeepCopy()
+		result, err := c.finalizers.Finalize(ctx, comp)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("running composition finalizers: %w", err)
 		}
-
-		// Deletion increments the composition's generation, but the reconstitution cache is only invalidated
-		// when the synthesized generation (from the status) changes, which will never happen because synthesis
-		// is righly disabled for deleted compositions. We break out of this deadlock condition by updating
-		// the status without actually synthesizing.
-		if comp.Status.CurrentState != nil && comp.Status.CurrentState.ObservedCompositionGeneration != comp.Generation {
-			comp.Status.CurrentState.ObservedCompositionGeneration = comp.Generation
-			comp.Status.CurrentState.Ready = false
-			comp.Status.CurrentState.Reconciled = false
-			comp.Status.CurrentState.Synthesized = true // in case the previous synthesis failed (TODO I don't think this actually works)
-			err = c.client.Status().Update(ctx, comp)
-			if err != nil {
-				return ctrl.Result{}, fmt.Errorf("updating current composition generation: %w", err)
+		if result.StatusUpdated {
+			if err := c.patchCompositionStatus(ctx, base, comp); err != nil {
+				return ctrl.Result{}, fmt.Errorf("updating composition status during finalization: %w", err)
 			}
-			logger.Info("updated composition status to reflect deletion")
-			return ctrl.Result{}, nil
-		}
-
-		// Remove the finalizer when all pods and slices have been deleted
-		if comp.Status.CurrentState != nil && (!comp.Status.CurrentState.Reconciled) || comp.Status.CurrentState.ObservedCompositionGeneration != comp.Generation {
-			logger.V(1).Info("refusing to remove composition finalizer because it is still being reconciled")
-			return ctrl.Result{}, nil
+			// The status patch just advanced comp's resourceVersion server-side. Carry that
+			// forward as the precondition for the metadata patch below without losing base's
+			// original (pre-Finalize) finalizer list, which is what we need to diff against.
+			base.ResourceVersion = comp.ResourceVersion
 		}
-		if hasRunningPod(pods) {
-			logger.V(1).Info("refusing to remove composition finalizer because at least one synthesizer pod still exists")
-			return ctrl.Result{}, nil
-		}
-		if controllerutil.RemoveFinalizer(comp, "eno.azure.io/cleanup") {
-			err = c.client.Update(ctx, comp)
-			if err != nil {
-				return ctrl.Result{}, fmt.Errorf("removing finalizer: %w", err)
+		if result.Updated {
+			if err := c.patchComposition(ctx, base, comp); err != nil {
+				return ctrl.Result{}, fmt.Errorf("updating composition during finalization: %w", err)
 			}
-
-			logger.Info("removed finalizer from composition")
 		}
-
+		logger.Info("ran composition finalizers", "updated", result.Updated, "statusUpdated", result.StatusUpdated)
 		return ctrl.Result{}, nil
 	}
 
 	// Swap the state to prepare for resynthesis if needed
 	if comp.Status.CurrentState == nil || comp.Status.CurrentState.ObservedCompositionGeneration != comp.Generation {
-		swapStates(comp)
-		if err := c.client.Status().Update(ctx, comp); err != nil {
+		if err := c.patchSynthesisStatus(ctx, comp, swapStates); err != nil {
 			return ctrl.Result{}, fmt.Errorf("swapping compisition state: %w", err)
 		}
 		logger.Info("start to synthesize")
@@ -159,6 +221,11 @@ func (c *podLifecycleController) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, nil
 	}
 
+	// Don't create new pods once the controller is draining ahead of its own Deployment deletion.
+	if c.draining.Load() {
+		return ctrl.Result{}, nil
+	}
+
 	// If we made it this far it's safe to create a pod
 	pod := newPod(c.config, c.client.Scheme(), comp, syn)
 	err = c.client.Create(ctx, pod)
@@ -171,9 +238,9 @@ func (c *podLifecycleController) Reconcile(ctx context.Context, req ctrl.Request
 	return ctrl.Result{}, nil
 }
 
-func shouldDeletePod(logger logr.Logger, comp *apiv1.Composition, syn *apiv1.Synthesizer, pods *corev1.PodList) (logr.Logger, *corev1.Pod, bool /* exists */) {
+func shouldDeletePod(logger logr.Logger, comp *apiv1.Composition, syn *apiv1.Synthesizer, pods *corev1.PodList) (logr.Logger, string, *corev1.Pod, bool /* exists */) {
 	if len(pods.Items) == 0 {
-		return logger, nil, false
+		return logger, "", nil, false
 	}
 
 	// Only create pods when the previous one is deleting or non-existant
@@ -182,7 +249,7 @@ func shouldDeletePod(logger logr.Logger, comp *apiv1.Composition, syn *apiv1.Syn
 		pod := pod
 		if comp.DeletionTimestamp != nil {
 			logger = logger.WithValues("reason", "CompositionDeleted")
-			return logger, &pod, true
+			return logger, "CompositionDeleted", &pod, true
 		}
 
 		// Allow a single extra pod to be created while the previous one is terminating
@@ -190,7 +257,7 @@ func shouldDeletePod(logger logr.Logger, comp *apiv1.Composition, syn *apiv1.Syn
 		// TODO: e2e test for this
 		if pod.DeletionTimestamp != nil {
 			if onePodDeleting {
-				return logger, nil, true
+				return logger, "", nil, true
 			}
 			onePodDeleting = true
 			continue
@@ -199,7 +266,7 @@ func shouldDeletePod(logger logr.Logger, comp *apiv1.Composition, syn *apiv1.Syn
 		isCurrent := podDerivedFrom(comp, &pod)
 		if !isCurrent {
 			logger = logger.WithValues("reason", "Superseded")
-			return logger, &pod, true
+			return logger, "Superseded", &pod, true
 		}
 
 		// Synthesis is done
@@ -208,7 +275,7 @@ func shouldDeletePod(logger logr.Logger, comp *apiv1.Composition, syn *apiv1.Syn
 				logger = logger.WithValues("latency", time.Since(comp.Status.CurrentState.PodCreation.Time).Milliseconds())
 			}
 			logger = logger.WithValues("reason", "Success")
-			return logger, &pod, true
+			return logger, "Success", &pod, true
 		}
 
 		// Pod is too old
@@ -216,13 +283,48 @@ func shouldDeletePod(logger logr.Logger, comp *apiv1.Composition, syn *apiv1.Syn
 		if time.Since(pod.CreationTimestamp.Time) > syn.Spec.PodTimeout.Duration {
 			logger = logger.WithValues("reason", "Timeout")
 			synthesPodRecreations.Inc()
-			return logger, &pod, true
+			return logger, "Timeout", &pod, true
 		}
 
 		// At this point the pod should still be running - no need to check other pods
-		return logger, nil, true
+		return logger, "", nil, true
+	}
+	return logger, "", nil, false
+}
+
+// podDisruptionReason prefers the DisruptionTarget condition Kubernetes sets when it evicts,
+// preempts, or taint-manager-deletes a pod, falling back to the reason shouldDeletePod derived.
+func podDisruptionReason(pod *corev1.Pod, fallback string) (string, string) {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type != disruptionTargetCondition || cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Reason {
+		case "PreemptionByKubeScheduler", "EvictionByEvictionAPI", "DeletionByTaintManager", "DeletionByPodGC":
+			return cond.Reason, cond.Message
+		}
+	}
+	return fallback, ""
+}
+
+// recordPodDisruption appends a disruption entry to comp's current synthesis, trimming the
+// oldest entries once maxPodDisruptionHistory is exceeded. Returns false if there's no current
+// synthesis to record against.
+func recordPodDisruption(comp *apiv1.Composition, podName, reason, message string) bool {
+	if comp.Status.CurrentState == nil || reason == "" {
+		return false
 	}
-	return logger, nil, false
+	comp.Status.CurrentState.PodDisruptions = append(comp.Status.CurrentState.PodDisruptions, apiv1.PodDisruption{
+		PodName: podName,
+		Reason:  reason,
+		Time:    metav1.Now(),
+		Message: message,
+	})
+	if n := len(comp.Status.CurrentState.PodDisruptions); n > maxPodDisruptionHistory {
+		comp.Status.CurrentState.PodDisruptions = comp.Status.CurrentState.PodDisruptions[n-maxPodDisruptionHistory:]
+	}
+	sythesesPodDisruptions.WithLabelValues(reason).Inc()
+	return true
 }
 
 func swapStates(comp *apiv1.Composition) {
@@ -235,6 +337,124 @@ func swapStates(comp *apiv1.Composition) {
 	}
 }
 
+// currentRunningPod returns the pod that's currently synthesizing comp, if any.
+// It mirrors the selection logic in shouldDeletePod without triggering a deletion.
+func currentRunningPod(comp *apiv1.Composition, pods *corev1.PodList) *corev1.Pod {
+	for _, pod := range pods.Items {
+		pod := pod
+		if pod.DeletionTimestamp == nil && podDerivedFrom(comp, &pod) {
+			return &pod
+		}
+	}
+	return nil
+}
+
+// podStrandedByNode reports whether pod's node has become unreachable, mirroring the
+// non-graceful node shutdown remediation in kube-controller-manager: a node that's been
+// NotReady past the configured threshold, that carries the out-of-service taint with effect
+// NoExecute, or that's gone entirely, can never report back that the pod has exited.
+func (c *podLifecycleController) podStrandedByNode(ctx context.Context, pod *corev1.Pod) (string, bool) {
+	if c.config.NodeUnreachableTimeout <= 0 || pod.Spec.NodeName == "" {
+		return "", false
+	}
+
+	node := &corev1.Node{}
+	if err := c.client.Get(ctx, client.ObjectKey{Name: pod.Spec.NodeName}, node); err != nil {
+		// A deleted node is a stronger signal than NotReady: it will certainly never report the
+		// pod as exited, so there's no reason to wait out NodeUnreachableTimeout first.
+		if apierrors.IsNotFound(err) {
+			return "NodeGone", true
+		}
+		return "", false
+	}
+
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == nodeOutOfServiceTaint && taint.Effect == corev1.TaintEffectNoExecute {
+			return "NodeOutOfService", true
+		}
+	}
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady && cond.Status != corev1.ConditionTrue && time.Since(cond.LastTransitionTime.Time) > c.config.NodeUnreachableTimeout {
+			return "NodeUnreachable", true
+		}
+	}
+	return "", false
+}
+
+// forceDeletePod strips any finalizers blocking the pod's removal and issues a force
+// delete, since a pod on an unreachable node will never be deleted gracefully by kubelet.
+func (c *podLifecycleController) forceDeletePod(ctx context.Context, pod *corev1.Pod) error {
+	if len(pod.Finalizers) > 0 {
+		patch := client.MergeFrom(pod.DeepCopy())
+		pod.Finalizers = nil
+		if err := c.client.Patch(ctx, pod, patch); err != nil {
+			return client.IgnoreNotFound(fmt.Errorf("removing finalizers: %w", err))
+		}
+	}
+
+	gracePeriod := int64(0)
+	err := c.client.Delete(ctx, pod, &client.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+	return client.IgnoreNotFound(err)
+}
+
+// finalizeLegacyCleanup is a migration shim: it has no cleanup work of its own, since the state-
+// revert/slice-cleanup/pod-drain finalizers above now cover what the old monolithic finalizer
+// used to do. It exists only so Compositions that still carry the pre-upgrade finalizer get it
+// stripped on their next deletion instead of being wedged forever. Safe to delete once no live
+// Composition carries legacyCleanupFinalizer anymore.
+func (c *podLifecycleController) finalizeLegacyCleanup(ctx context.Context, comp *apiv1.Composition) (FinalizerResult, error) {
+	return FinalizerResult{Done: true}, nil
+}
+
+// finalizeStateRevert swaps the previous synthesis back to current if the composition was mid
+// synthesis when it was deleted. Otherwise we'd get stuck waiting for a synthesis that can't happen.
+func (c *podLifecycleController) finalizeStateRevert(ctx context.Context, comp *apiv1.Composition) (FinalizerResult, error) {
+	if comp.Status.CurrentState != nil && comp.Status.CurrentState.Synthesized {
+		return FinalizerResult{Done: true}, nil
+	}
+	if comp.Status.CurrentState == nil && comp.Status.PreviousState == nil {
+		return FinalizerResult{Done: true}, nil // nothing left to revert
+	}
+	comp.Status.CurrentState = comp.Status.PreviousState
+	comp.Status.PreviousState = nil
+	return FinalizerResult{StatusUpdated: true}, nil
+}
+
+// finalizeSliceCleanup bumps ObservedCompositionGeneration so the resource reconstitution cache
+// is invalidated even though synthesis is (rightly) disabled for a deleted composition. Deletion
+// increments the composition's generation, but the cache is only invalidated when the synthesized
+// generation changes, which otherwise never happens.
+func (c *podLifecycleController) finalizeSliceCleanup(ctx context.Context, comp *apiv1.Composition) (FinalizerResult, error) {
+	if comp.Status.CurrentState == nil || comp.Status.CurrentState.ObservedCompositionGeneration == comp.Generation {
+		return FinalizerResult{Done: true}, nil
+	}
+	comp.Status.CurrentState.ObservedCompositionGeneration = comp.Generation
+	comp.Status.CurrentState.Ready = false
+	comp.Status.CurrentState.Reconciled = false
+	comp.Status.CurrentState.Synthesized = true // in case the previous synthesis failed (TODO I don't think this actually works)
+	return FinalizerResult{StatusUpdated: true}, nil
+}
+
+// finalizePodDrain waits for the composition's synthesis to be fully reconciled and for every
+// synthesizer pod to be gone or terminating (DeletionTimestamp set) before releasing its finalizer.
+func (c *podLifecycleController) finalizePodDrain(ctx context.Context, comp *apiv1.Composition) (FinalizerResult, error) {
+	if comp.Status.CurrentState != nil && (!comp.Status.CurrentState.Reconciled || comp.Status.CurrentState.ObservedCompositionGeneration != comp.Generation) {
+		return FinalizerResult{}, nil // still being reconciled
+	}
+
+	pods := &corev1.PodList{}
+	if err := c.client.List(ctx, pods, client.InNamespace(comp.Namespace), client.MatchingFields{
+		manager.IdxPodsByComposition: comp.Name,
+	}); err != nil {
+		return FinalizerResult{}, fmt.Errorf("listing pods: %w", err)
+	}
+	if hasRunningPod(pods) {
+		return FinalizerResult{}, nil // at least one synthesizer pod still exists
+	}
+
+	return FinalizerResult{Done: true}, nil
+}
+
 func hasRunningPod(list *corev1.PodList) bool {
 	for _, pod := range list.Items {
 		if pod.DeletionTimestamp == nil {
@@ -0,0 +1,198 @@
+package synthesis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	apiv1 "github.com/Azure/eno/api/v1"
+	"github.com/Azure/eno/internal/manager"
+)
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := apiv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+// TestReconcileAddsAllCleanupFinalizers guards against a live Composition ending up with a
+// registered finalizer (e.g. sliceCleanupFinalizer) that Reconcile never actually adds, which
+// would leave its Finalize step permanently unreachable.
+func TestReconcileAddsAllCleanupFinalizers(t *testing.T) {
+	comp := &apiv1.Composition{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}}
+	fakeClient := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(comp).Build()
+
+	c := &podLifecycleController{client: fakeClient, config: &Config{}, finalizers: NewCompositionFinalizer()}
+	c.registerFinalizers()
+
+	ctx := context.Background()
+	if _, err := c.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(comp)}); err != nil {
+		t.Fatal(err)
+	}
+
+	updated := &apiv1.Composition{}
+	if err := fakeClient.Get(ctx, client.ObjectKeyFromObject(comp), updated); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{stateRevertFinalizer, sliceCleanupFinalizer, podDrainFinalizer} {
+		if !controllerutil.ContainsFinalizer(updated, name) {
+			t.Errorf("expected %q to be added to the composition's finalizers, got %v", name, updated.Finalizers)
+		}
+	}
+}
+
+// TestFinalizeDrainsFullySynthesizedComposition drives a fully-synthesized, fully-reconciled
+// Composition through deletion and asserts its finalizers eventually empty. This reproduces the
+// deadlock that results if sliceCleanupFinalizer is registered but never added/run: pod-drain
+// refuses to finish until ObservedCompositionGeneration catches up to Generation, which only
+// finalizeSliceCleanup does.
+func TestFinalizeDrainsFullySynthesizedComposition(t *testing.T) {
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(newTestScheme(t)).
+		WithIndex(&corev1.Pod{}, manager.IdxPodsByComposition, func(client.Object) []string { return nil }).
+		Build()
+
+	c := &podLifecycleController{client: fakeClient, config: &Config{}, finalizers: NewCompositionFinalizer()}
+	c.registerFinalizers()
+
+	now := metav1.Now()
+	comp := &apiv1.Composition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "test",
+			Namespace:         "default",
+			Generation:        2,
+			Finalizers:        []string{stateRevertFinalizer, sliceCleanupFinalizer, podDrainFinalizer},
+			DeletionTimestamp: &now,
+		},
+		Status: apiv1.CompositionStatus{
+			CurrentState: &apiv1.Synthesis{
+				ObservedCompositionGeneration: 1,
+				Synthesized:                   true,
+				Reconciled:                    true,
+			},
+		},
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 5 && len(comp.Finalizers) > 0; i++ {
+		if _, err := c.finalizers.Finalize(ctx, comp); err != nil {
+			t.Fatalf("finalize pass %d: %v", i, err)
+		}
+
+		// Simulate the resource-reconciliation controller catching up to the generation bump
+		// finalizeSliceCleanup just made - that's what actually unblocks pod-drain.
+		if comp.Status.CurrentState != nil && !comp.Status.CurrentState.Reconciled {
+			comp.Status.CurrentState.Reconciled = true
+		}
+	}
+
+	if controllerutil.ContainsFinalizer(comp, sliceCleanupFinalizer) {
+		t.Fatal("slice-cleanup finalizer was never removed - finalizeSliceCleanup never ran")
+	}
+	if len(comp.Finalizers) != 0 {
+		t.Fatalf("expected all finalizers to be removed once synthesis is reconciled, got %v", comp.Finalizers)
+	}
+}
+
+// TestPodStrandedByNode covers the heuristics podStrandedByNode uses to decide a synthesizer pod
+// will never be reported as exited by kubelet: an out-of-service taint, a node that's been
+// NotReady past NodeUnreachableTimeout, a node that hasn't been NotReady long enough yet, and a
+// node that's been deleted outright (NodeGone), which should be treated as stranded immediately.
+func TestPodStrandedByNode(t *testing.T) {
+	const timeout = time.Minute
+
+	cases := []struct {
+		name         string
+		node         *corev1.Node
+		wantReason   string
+		wantStranded bool
+	}{
+		{
+			name:         "healthy node",
+			node:         &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+			wantStranded: false,
+		},
+		{
+			name: "out of service taint",
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+				Spec: corev1.NodeSpec{
+					Taints: []corev1.Taint{{Key: nodeOutOfServiceTaint, Effect: corev1.TaintEffectNoExecute}},
+				},
+			},
+			wantReason:   "NodeOutOfService",
+			wantStranded: true,
+		},
+		{
+			name: "not ready past threshold",
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{{
+						Type:               corev1.NodeReady,
+						Status:             corev1.ConditionFalse,
+						LastTransitionTime: metav1.NewTime(time.Now().Add(-2 * timeout)),
+					}},
+				},
+			},
+			wantReason:   "NodeUnreachable",
+			wantStranded: true,
+		},
+		{
+			name: "not ready but not past threshold yet",
+			node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{{
+						Type:               corev1.NodeReady,
+						Status:             corev1.ConditionFalse,
+						LastTransitionTime: metav1.Now(),
+					}},
+				},
+			},
+			wantStranded: false,
+		},
+		{
+			name:         "node gone",
+			node:         nil,
+			wantReason:   "NodeGone",
+			wantStranded: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(newTestScheme(t))
+			if tc.node != nil {
+				builder = builder.WithObjects(tc.node)
+			}
+			c := &podLifecycleController{
+				client: builder.Build(),
+				config: &Config{NodeUnreachableTimeout: timeout},
+			}
+
+			pod := &corev1.Pod{Spec: corev1.PodSpec{NodeName: "node-1"}}
+			reason, stranded := c.podStrandedByNode(context.Background(), pod)
+			if stranded != tc.wantStranded {
+				t.Fatalf("expected stranded=%v, got %v (reason %q)", tc.wantStranded, stranded, reason)
+			}
+			if tc.wantStranded && reason != tc.wantReason {
+				t.Fatalf("expected reason %q, got %q", tc.wantReason, reason)
+			}
+		})
+	}
+}
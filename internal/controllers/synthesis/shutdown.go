@@ -0,0 +1,178 @@
+package synthesis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	apiv1 "github.com/Azure/eno/api/v1"
+	"github.com/Azure/eno/internal/manager"
+)
+
+// deploymentWatcher drains the controller's own pod lifecycle management once the controller's
+// Deployment starts deleting, so Compositions aren't left wedged with finalizers the controller
+// will never again be running to remove. This is the pattern config-policy-controller adopted to
+// avoid stranded CRs when its own Deployment is removed.
+type deploymentWatcher struct {
+	client       client.Client
+	ctrl         *podLifecycleController
+	pollInterval time.Duration
+}
+
+// WatchOwnDeployment registers a manager runnable that watches the controller's own Deployment,
+// identified via the downward-API POD_NAMESPACE/POD_NAME env vars, and drains c once that
+// Deployment starts deleting. It's a no-op unless cfg.RemoveFinalizersOnShutdown is set, since
+// operators may prefer to leave resources dangling rather than have the controller mutate
+// Compositions out from under them during an uninstall.
+func WatchOwnDeployment(mgr ctrl.Manager, cfg *Config, c *podLifecycleController) error {
+	if !cfg.RemoveFinalizersOnShutdown {
+		return nil
+	}
+	return mgr.Add(&deploymentWatcher{client: mgr.GetClient(), ctrl: c, pollInterval: 10 * time.Second})
+}
+
+func (w *deploymentWatcher) Start(ctx context.Context) error {
+	ns, name := os.Getenv("POD_NAMESPACE"), os.Getenv("POD_NAME")
+	if ns == "" || name == "" {
+		// Misconfiguration, but not fatal: RemoveFinalizersOnShutdown is opt-in, so failing to
+		// watch our own deployment shouldn't crash the rest of the controller via mgr.Start().
+		logr.FromContextOrDiscard(ctx).Info("POD_NAMESPACE and POD_NAME must be set to watch the controller's own deployment - drain-on-shutdown is disabled")
+		return nil
+	}
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			deleting, err := w.ownDeploymentDeleting(ctx, ns, name)
+			if err != nil {
+				logr.FromContextOrDiscard(ctx).Error(err, "checking whether own deployment is being deleted")
+				continue
+			}
+			if deleting {
+				w.ctrl.drain(ctx)
+				return nil
+			}
+		}
+	}
+}
+
+// ownDeploymentDeleting walks pod -> owning ReplicaSet -> owning Deployment and reports whether
+// the Deployment has a DeletionTimestamp set (or is already gone).
+func (w *deploymentWatcher) ownDeploymentDeleting(ctx context.Context, ns, podName string) (bool, error) {
+	pod := &corev1.Pod{}
+	if err := w.client.Get(ctx, client.ObjectKey{Namespace: ns, Name: podName}, pod); err != nil {
+		return false, fmt.Errorf("getting own pod: %w", err)
+	}
+
+	rsName := ownerName(pod.OwnerReferences, "ReplicaSet")
+	if rsName == "" {
+		return false, nil
+	}
+	rs := &appsv1.ReplicaSet{}
+	if err := w.client.Get(ctx, client.ObjectKey{Namespace: ns, Name: rsName}, rs); err != nil {
+		return false, fmt.Errorf("getting owning replicaset: %w", err)
+	}
+
+	depName := ownerName(rs.OwnerReferences, "Deployment")
+	if depName == "" {
+		return false, nil
+	}
+
+	dep := &appsv1.Deployment{}
+	err := w.client.Get(ctx, client.ObjectKey{Namespace: ns, Name: depName}, dep)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("getting owning deployment: %w", err)
+	}
+	return dep.DeletionTimestamp != nil, nil
+}
+
+func ownerName(refs []metav1.OwnerReference, kind string) string {
+	for _, ref := range refs {
+		if ref.Kind == kind {
+			return ref.Name
+		}
+	}
+	return ""
+}
+
+// drain puts the controller into shutdown mode: it stops creating new synthesizer pods,
+// best-effort deletes any that still exist, and strips every cleanup finalizer this controller
+// knows about from every Composition regardless of reconciled state, so namespace and CRD
+// deletion aren't wedged behind a controller that's no longer running.
+func (c *podLifecycleController) drain(ctx context.Context) {
+	logger := logr.FromContextOrDiscard(ctx).WithName("deploymentWatcher")
+	logger.Info("controller deployment is being deleted, draining pod lifecycle management")
+	c.draining.Store(true)
+
+	comps := &apiv1.CompositionList{}
+	if err := c.client.List(ctx, comps); err != nil {
+		logger.Error(err, "listing compositions during drain")
+		return
+	}
+
+	for i := range comps.Items {
+		comp := &comps.Items[i]
+
+		pods := &corev1.PodList{}
+		if err := c.client.List(ctx, pods, client.InNamespace(comp.Namespace), client.MatchingFields{
+			manager.IdxPodsByComposition: comp.Name,
+		}); err != nil {
+			logger.Error(err, "listing pods during drain", "compositionName", comp.Name)
+		} else {
+			for j := range pods.Items {
+				if err := c.client.Delete(ctx, &pods.Items[j]); err != nil && !apierrors.IsNotFound(err) {
+					logger.Error(err, "deleting pod during drain", "podName", pods.Items[j].Name)
+				}
+			}
+		}
+
+		var removed bool
+		for _, name := range c.finalizers.Names() {
+			if controllerutil.RemoveFinalizer(comp, name) {
+				removed = true
+			}
+		}
+		if !removed {
+			continue
+		}
+
+		// drain only runs once, so a single stale-resourceVersion conflict would otherwise
+		// permanently strand this composition's finalizers - retry against the latest version.
+		name := client.ObjectKeyFromObject(comp)
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			err := c.client.Update(ctx, comp)
+			if apierrors.IsConflict(err) {
+				latest := &apiv1.Composition{}
+				if getErr := c.client.Get(ctx, name, latest); getErr != nil {
+					return getErr
+				}
+				for _, fname := range c.finalizers.Names() {
+					controllerutil.RemoveFinalizer(latest, fname)
+				}
+				comp = latest
+			}
+			return err
+		})
+		if err != nil {
+			logger.Error(err, "removing finalizers during drain", "compositionName", name.Name)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package synthesis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/Azure/eno/api/v1"
+	"github.com/Azure/eno/internal/manager"
+)
+
+// TestWatchOwnDeploymentDisabledByDefault asserts that WatchOwnDeployment never touches the
+// manager (and so never registers the drain runnable) unless RemoveFinalizersOnShutdown is set,
+// since operators may prefer to leave resources dangling during an uninstall.
+func TestWatchOwnDeploymentDisabledByDefault(t *testing.T) {
+	if err := WatchOwnDeployment(nil, &Config{}, &podLifecycleController{}); err != nil {
+		t.Fatalf("expected WatchOwnDeployment to no-op when disabled, got error: %v", err)
+	}
+}
+
+// conflictOnceClient fails the first Update call for each object name with a Conflict error,
+// simulating a concurrent writer racing drain()'s own Update, then succeeds on retry.
+type conflictOnceClient struct {
+	client.Client
+	failed map[string]bool
+}
+
+func (c *conflictOnceClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if !c.failed[obj.GetName()] {
+		c.failed[obj.GetName()] = true
+		return apierrors.NewConflict(schema.GroupResource{Group: "eno.azure.io", Resource: "compositions"}, obj.GetName(), fmt.Errorf("stale resourceVersion"))
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+// TestDrainRetriesOnConflict asserts that drain() retries past a stale-resourceVersion conflict
+// on its finalizer-removing Update instead of giving up, since drain only runs once and has no
+// later reconcile to heal a composition left stranded by a single conflict.
+func TestDrainRetriesOnConflict(t *testing.T) {
+	comp := &apiv1.Composition{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", Finalizers: []string{stateRevertFinalizer}},
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(newTestScheme(t)).
+		WithObjects(comp).
+		WithIndex(&corev1.Pod{}, manager.IdxPodsByComposition, func(client.Object) []string { return nil }).
+		Build()
+
+	wrapped := &conflictOnceClient{Client: fakeClient, failed: map[string]bool{}}
+	c := &podLifecycleController{client: wrapped, config: &Config{}, finalizers: NewCompositionFinalizer()}
+	c.registerFinalizers()
+
+	c.drain(context.Background())
+
+	updated := &apiv1.Composition{}
+	if err := fakeClient.Get(context.Background(), client.ObjectKeyFromObject(comp), updated); err != nil {
+		t.Fatal(err)
+	}
+	if len(updated.Finalizers) != 0 {
+		t.Fatalf("expected finalizers to be removed after retrying past the conflict, got %v", updated.Finalizers)
+	}
+	if !c.draining.Load() {
+		t.Fatal("expected drain to mark the controller as draining")
+	}
+}